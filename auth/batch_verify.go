@@ -0,0 +1,331 @@
+package auth
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	crypto "github.com/tendermint/go-crypto"
+	types "github.com/FourthState/plasma-mvp-sidechain/types"
+	utils "github.com/FourthState/plasma-mvp-sidechain/utils"
+)
+
+// sigTriple is one (hash, signature, expected signer) check pulled out of
+// a spend so it can be verified off the per-tx ante handler's goroutine.
+type sigTriple struct {
+	txIndex   int
+	label     string
+	signBytes []byte
+	wireSig   []byte
+	expected  crypto.Address
+}
+
+// BatchVerifier collects every spend and confirm signature across a whole
+// block and verifies them concurrently, instead of paying the cost of
+// recovering a public key once per signature on DeliverTx's single
+// goroutine. Usage is two-phase: call Collect once per transaction (via
+// NewBatchAnteHandler) to queue its signatures without verifying them, then
+// call VerifyAll once for the whole block. Reset clears the queue for the
+// next block.
+//
+// Deferring verification past Collect is only safe if nothing built on the
+// unverified signature before VerifyAll runs: the SDK applies each
+// message's state mutation immediately after its ante handler returns, so
+// by the time VerifyAll finishes, a later transaction in the same block may
+// already have spent an output a forged signature created. Discarding only
+// the one failing transaction at that point is not enough to undo that.
+// DeliverBlock is the supported way to drive a block through this
+// verifier: it runs every transaction's delivery against an isolated cache
+// of the store and only writes that cache back if VerifyAll passes,
+// discarding the whole block atomically otherwise. Do not install
+// NewBatchAnteHandler as BaseApp's direct per-tx DeliverTx ante handler
+// outside of that cache, since nothing would then undo a later
+// transaction's already-committed state mutation if VerifyAll failed.
+type BatchVerifier struct {
+	UTXOMapper       types.UTXOMapper
+	Registry         *SchemeRegistry
+	ConfirmSigPolicy ConfirmSigPolicy
+	Workers          int
+
+	mu      sync.Mutex
+	triples []sigTriple
+}
+
+// NewBatchVerifier returns a BatchVerifier with Workers sized to
+// GOMAXPROCS, matching the CPU budget actually available to verify
+// signatures in parallel. confirmSigPolicy may be nil, which behaves like
+// PlasmaMVPPolicy: every confirm sig is required and queued.
+func NewBatchVerifier(utxoMapper types.UTXOMapper, registry *SchemeRegistry, confirmSigPolicy ConfirmSigPolicy) *BatchVerifier {
+	return &BatchVerifier{
+		UTXOMapper:       utxoMapper,
+		Registry:         registry,
+		ConfirmSigPolicy: confirmSigPolicy,
+		Workers:          runtime.GOMAXPROCS(0),
+	}
+}
+
+// Reset clears every triple queued for the previous block.
+func (v *BatchVerifier) Reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.triples = nil
+}
+
+// Collect extracts every (hash, sig, expectedAddr) triple out of txIndex's
+// spend message and queues them for VerifyAll, without verifying any of
+// them yet. Confirm sigs follow ConfirmSigPolicy (PlasmaMVPPolicy if unset)
+// and queueConfirmSigs's own handling of an aggregate scheme, the same way
+// SpendMsgHandler and processConfirmSig do for the inline path.
+func (v *BatchVerifier) Collect(ctx sdk.Context, txIndex int, spendMsg types.SpendMsg, signerAddrs []crypto.Address, sigs []sdk.StdSignature) error {
+	signBytes := spendMsg.GetSignBytes()
+
+	position1 := types.Position{spendMsg.Blknum1, spendMsg.Txindex1, spendMsg.Oindex1, spendMsg.DepositNum1}
+	utxo1 := v.UTXOMapper.GetUTXO(ctx, position1)
+	if utxo1 == nil {
+		return fmt.Errorf("tx %d: UTXO trying to be spent, does not exist", txIndex)
+	}
+	v.queue(txIndex, "input 1", signBytes, sigs[0].Signature.Bytes()[5:], signerAddrs[0])
+	if !v.confirmSigExempt(ctx, position1) {
+		if err := v.queueConfirmSigs(txIndex, "confirm 1", position1.GetSignBytes(), spendMsg.ConfirmSigs1, utxo1.GetInputAddresses()); err != nil {
+			return err
+		}
+	}
+
+	if utils.ValidAddress(spendMsg.Owner2) {
+		position2 := types.Position{spendMsg.Blknum2, spendMsg.Txindex2, spendMsg.Oindex2, spendMsg.DepositNum2}
+		utxo2 := v.UTXOMapper.GetUTXO(ctx, position2)
+		if utxo2 == nil {
+			return fmt.Errorf("tx %d: UTXO trying to be spent, does not exist", txIndex)
+		}
+		v.queue(txIndex, "input 2", signBytes, sigs[1].Signature.Bytes()[5:], signerAddrs[1])
+		if !v.confirmSigExempt(ctx, position2) {
+			if err := v.queueConfirmSigs(txIndex, "confirm 2", position2.GetSignBytes(), spendMsg.ConfirmSigs2, utxo2.GetInputAddresses()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// confirmSigExempt reports whether v.ConfirmSigPolicy (PlasmaMVPPolicy if
+// unset) has already decided, without verifying anything, that position's
+// confirm sig need not be checked at all.
+func (v *BatchVerifier) confirmSigExempt(ctx sdk.Context, position types.Position) bool {
+	exemptor, ok := v.ConfirmSigPolicy.(ConfirmSigExemptor)
+	return ok && exemptor.ConfirmSigExempt(ctx, position)
+}
+
+// queueConfirmSigs queues a confirm signature for each of the referenced
+// UTXO's input addresses, mirroring processConfirmSig's handling of a UTXO
+// that was itself created by a one- or two-input spend. An aggregate
+// scheme (e.g. BLS) covering both input addresses at once is verified
+// immediately instead of being queued: BatchVerifier's per-address triples
+// have no way to represent a single signature checked against more than
+// one address.
+func (v *BatchVerifier) queueConfirmSigs(txIndex int, label string, posSignBytes []byte, confirmSigs [2]crypto.Signature, inputAddresses []crypto.Address) error {
+	wireSig0 := sigBytes(confirmSigs[0])
+	scheme0, rawSig0, err := v.Registry.Lookup(wireSig0)
+	if err != nil {
+		return fmt.Errorf("tx %d %s: %s", txIndex, label, err)
+	}
+
+	if aggScheme, ok := scheme0.(AggregateScheme); ok && utils.ValidAddress(inputAddresses[1]) {
+		if err := aggScheme.VerifyAggregate(inputAddresses, posSignBytes, rawSig0); err != nil {
+			return fmt.Errorf("tx %d %s: aggregate confirm signature verification failed", txIndex, label)
+		}
+		return nil
+	}
+
+	v.queue(txIndex, label+" (1)", posSignBytes, wireSig0, inputAddresses[0])
+	if utils.ValidAddress(inputAddresses[1]) {
+		v.queue(txIndex, label+" (2)", posSignBytes, sigBytes(confirmSigs[1]), inputAddresses[1])
+	}
+	return nil
+}
+
+func sigBytes(sig crypto.Signature) []byte {
+	return sig.(crypto.SignatureSecp256k1).Bytes()[5:]
+}
+
+func (v *BatchVerifier) queue(txIndex int, label string, signBytes, wireSig []byte, expected crypto.Address) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.triples = append(v.triples, sigTriple{txIndex: txIndex, label: label, signBytes: signBytes, wireSig: wireSig, expected: expected})
+}
+
+// VerifyAll verifies every queued triple across Workers goroutines and
+// returns the index of the lowest-numbered failing transaction, or -1 if
+// every signature in the block is valid. It always verifies every queued
+// triple rather than stopping at the first failure a goroutine happens to
+// finish: goroutines race and complete out of order, so stopping early
+// could report a later tx's failure while an earlier tx's forged signature
+// goes unnoticed, and that choice would differ from one node to the next.
+// Reporting the minimum index keeps the result the same on every node
+// regardless of how the verification work happened to be scheduled.
+func (v *BatchVerifier) VerifyAll() (failedTxIndex int, err error) {
+	if len(v.triples) == 0 {
+		return -1, nil
+	}
+
+	workers := v.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan sigTriple)
+	var failedIndex int32 = -1
+	var failedErr atomic.Value
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				verr := v.verifyOne(t)
+				if verr == nil {
+					continue
+				}
+				for {
+					cur := atomic.LoadInt32(&failedIndex)
+					if cur != -1 && int32(t.txIndex) >= cur {
+						break
+					}
+					if atomic.CompareAndSwapInt32(&failedIndex, cur, int32(t.txIndex)) {
+						failedErr.Store(verr)
+						break
+					}
+				}
+			}
+		}()
+	}
+
+	for _, t := range v.triples {
+		jobs <- t
+	}
+	close(jobs)
+	wg.Wait()
+
+	idx := atomic.LoadInt32(&failedIndex)
+	if idx == -1 {
+		return -1, nil
+	}
+	return int(idx), failedErr.Load().(error)
+}
+
+func (v *BatchVerifier) verifyOne(t sigTriple) error {
+	scheme, rawSig, err := v.Registry.Lookup(t.wireSig)
+	if err != nil {
+		return fmt.Errorf("tx %d %s: %s", t.txIndex, t.label, err)
+	}
+	if err := verifyScheme(scheme, t.signBytes, rawSig, t.expected); err != nil {
+		return fmt.Errorf("tx %d %s: signature verification failed", t.txIndex, t.label)
+	}
+	return nil
+}
+
+// DeliverBlock runs every tx in txs through deliverTx — which is expected
+// to install NewBatchAnteHandler(verifier) and apply each message's state
+// mutation as usual — against an isolated cache of ctx's store, instead of
+// against ctx directly. Once every tx has run, it calls verifier.VerifyAll:
+// only if that passes does it write the cache back to ctx's store, making
+// every tx's state mutation visible, and add every tx's fee (read back off
+// the context deliverTx returned for it) to feeAmount; otherwise the
+// entire cache is discarded and feeAmount is left untouched, so a failing
+// block cannot inflate the collected-fee total with transactions that were
+// never actually admitted. This is also why NewBatchAnteHandler's own
+// chain does not include IncrementFeeCounterDecorator: crediting feeAmount
+// per tx, before VerifyAll has run, would be a plain pointer mutation
+// DeliverBlock's cache rollback has no way to undo. The caller is
+// responsible for calling verifier.Reset() beforehand (DeliverBlock does
+// not, so it can be driven by a caller that already reset it at
+// BeginBlock).
+func (v *BatchVerifier) DeliverBlock(ctx sdk.Context, txs []sdk.Tx, feeAmount *uint64, deliverTx func(sdk.Context, sdk.Tx) (sdk.Context, sdk.Result)) (results []sdk.Result, failedTxIndex int, err error) {
+	cacheCtx, writeCache := ctx.CacheContext()
+
+	results = make([]sdk.Result, len(txs))
+	var totalFee uint64
+	for i, tx := range txs {
+		var txCtx sdk.Context
+		txCtx, results[i] = deliverTx(cacheCtx, tx)
+		totalFee += AggregateFee(txCtx)
+	}
+
+	failedTxIndex, err = v.VerifyAll()
+	if err != nil {
+		return nil, failedTxIndex, fmt.Errorf("block rejected, discarding all %d txs: %s", len(txs), err)
+	}
+
+	writeCache()
+	*feeAmount += totalFee
+	return results, -1, nil
+}
+
+// NewBatchAnteHandler returns an AnteHandler for DeliverTx that defers a
+// lone SpendMsg's signature verification to verifier instead of recovering
+// public keys inline — the common case this batch path optimizes for.
+// Anything else (a DepositMsg, ExitMsg, IncludeBlockMsg, or a
+// multi-message tx) is verified inline through router instead, exactly as
+// NewAnteHandler would, so those message types still reach DeliverTx.
+// BaseApp must drive this handler through BatchVerifier.DeliverBlock, not
+// call it directly: DeliverBlock is what makes deferring SpendMsg
+// verification past the message handler's state mutation (and past the
+// fee this handler stamps onto the context) safe, by rolling back both
+// atomically if VerifyAll fails. There is deliberately no
+// IncrementFeeCounterDecorator in this chain; see DeliverBlock's doc
+// comment for why. CheckTx should keep using NewAnteHandler, which
+// verifies inline since mempool transactions do not arrive as a batch.
+func NewBatchAnteHandler(utxoMapper types.UTXOMapper, txIndex *uint16, verifier *BatchVerifier, router *MsgRouter) sdk.AnteHandler {
+	collect := CollectSigsDecorator{Verifier: verifier, Index: txIndex, Router: router}
+	return ChainAnteDecorators(
+		ValidateBasicDecorator{},
+		collect,
+	)
+}
+
+// CollectSigsDecorator queues a lone SpendMsg's signatures with a
+// BatchVerifier instead of verifying them inline; everything else falls
+// back to MultiMsgDecorator, the same routing NewAnteHandler uses, so
+// DepositMsg, ExitMsg, IncludeBlockMsg, and multi-message transactions are
+// still verified (inline) rather than rejected outright. Index is
+// incremented once per batched SpendMsg, so the index VerifyAll reports is
+// the failing SpendMsg's position among the block's batched SpendMsgs;
+// transactions verified inline do not advance it, so it is not the
+// transaction's position in the block when such transactions precede it.
+type CollectSigsDecorator struct {
+	Verifier *BatchVerifier
+	Index    *uint16
+	Router   *MsgRouter
+}
+
+func (d CollectSigsDecorator) AnteHandle(ctx sdk.Context, tx sdk.Tx, next sdk.AnteHandler) (sdk.Context, sdk.Result, bool) {
+	msgs := tx.GetMsgs()
+	spendMsg, ok := soleSpendMsg(msgs)
+	if !ok {
+		return MultiMsgDecorator{Router: d.Router}.AnteHandle(ctx, tx, next)
+	}
+
+	txIdx := int(*d.Index)
+	*d.Index++
+
+	if err := d.Verifier.Collect(ctx, txIdx, spendMsg, msgs[0].GetSigners(), tx.GetSignatures()); err != nil {
+		return ctx, sdk.ErrUnknownRequest(err.Error()).Result(), true
+	}
+
+	ctx = ctx.WithValue(aggregateFeeContextKey{}, spendMsg.Fee)
+
+	return next(ctx, tx)
+}
+
+// soleSpendMsg reports whether msgs is exactly one SpendMsg, the shape
+// BatchVerifier's deferred-verification optimization applies to.
+func soleSpendMsg(msgs []sdk.Msg) (types.SpendMsg, bool) {
+	if len(msgs) != 1 {
+		return types.SpendMsg{}, false
+	}
+	spendMsg, ok := msgs[0].(types.SpendMsg)
+	return spendMsg, ok
+}
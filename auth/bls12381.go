@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"errors"
+
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+var errVerificationFailed = errors.New("BLS aggregate signature verification failed")
+
+// bls12381VerifyAggregate checks a 96-byte compressed G1 BLS12-381
+// signature against the sum of pubKeys (each a 48-byte compressed G2
+// point) over H(signBytes) mapped into G2:
+//
+//	e(g1, aggSig) == e(pk1 + pk2 + ..., H(signBytes))
+func bls12381VerifyAggregate(pubKeys [][]byte, signBytes []byte, aggSig []byte) error {
+	g1 := bls12381.NewG1()
+	g2 := bls12381.NewG2()
+
+	sig, err := g1.FromCompressed(aggSig)
+	if err != nil {
+		return err
+	}
+
+	aggPK := g2.Zero()
+	for _, raw := range pubKeys {
+		pk, err := g2.FromCompressed(raw)
+		if err != nil {
+			return err
+		}
+		g2.Add(aggPK, aggPK, pk)
+	}
+
+	msg := g2.MapToCurve(signBytes)
+
+	engine := bls12381.NewEngine()
+	engine.AddPair(g1.One(), sig)
+	engine.AddPairInv(aggPK, msg)
+	if !engine.Result().IsOne() {
+		return errVerificationFailed
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	crypto "github.com/tendermint/go-crypto"
+	types "github.com/FourthState/plasma-mvp-sidechain/types"
+)
+
+// ConfirmSigPolicy decides how a spent input's confirmation signature(s)
+// are checked. The default, PlasmaMVPPolicy, verifies them against the
+// referenced UTXO's input addresses exactly as processConfirmSig always
+// has; TendermintFinalityPolicy instead trusts a Tendermint validator
+// commit and skips confirm-sig verification once the block is finalized.
+// Plugging in a policy lets operators migrate between trust models without
+// touching SpendMsg itself.
+type ConfirmSigPolicy interface {
+	CheckConfirmSig(ctx sdk.Context, utxoMapper types.UTXOMapper, position types.Position, confirmSigs [2]crypto.Signature, posSignBytes []byte, registry *SchemeRegistry) sdk.Result
+}
+
+// ConfirmSigExemptor is implemented by a ConfirmSigPolicy that can report,
+// without verifying any signature, whether it has already decided to skip
+// confirm-sig checking for a position outright (TendermintFinalityPolicy
+// once the block is finalized). BatchVerifier type-asserts against this so
+// it can skip queuing a confirm-sig triple for an exempt position instead
+// of requiring (and failing to find) a signature that was never produced.
+type ConfirmSigExemptor interface {
+	ConfirmSigExempt(ctx sdk.Context, position types.Position) bool
+}
+
+// PlasmaMVPPolicy is today's behavior: an operator can withhold a Plasma
+// block, so every spend of an input must carry a confirmation signature
+// proving its owner has seen the block it was included in.
+type PlasmaMVPPolicy struct{}
+
+func (PlasmaMVPPolicy) CheckConfirmSig(ctx sdk.Context, utxoMapper types.UTXOMapper, position types.Position, confirmSigs [2]crypto.Signature, posSignBytes []byte, registry *SchemeRegistry) sdk.Result {
+	return processConfirmSig(ctx, utxoMapper, position, confirmSigs, posSignBytes, registry)
+}
+
+// HeaderStore resolves the block header for a Plasma block number and
+// reports whether a Tendermint validator set has already finalized it with
+// a +2/3 commit.
+type HeaderStore interface {
+	// HasCommit reports whether a header is indexed for height, and if so,
+	// whether it carries a commit signed by at least two-thirds of the
+	// voting power.
+	HasCommit(ctx sdk.Context, height uint64) (found bool, validCommit bool)
+}
+
+// TendermintFinalityPolicy skips confirm-sig verification once the block
+// containing the spent input carries a Tendermint commit from two-thirds
+// or more of the validator set: a BFT validator set running the sidechain
+// cannot withhold a block it has already finalized, so the confirmation
+// signature Plasma MVP relies on to catch a withholding operator is
+// redundant in that case. A two-input spend then drops from four
+// signatures to two in the common case. When the header isn't indexed yet
+// or was never finalized, it falls back to PlasmaMVPPolicy.
+type TendermintFinalityPolicy struct {
+	Headers HeaderStore
+}
+
+func (p TendermintFinalityPolicy) CheckConfirmSig(ctx sdk.Context, utxoMapper types.UTXOMapper, position types.Position, confirmSigs [2]crypto.Signature, posSignBytes []byte, registry *SchemeRegistry) sdk.Result {
+	if p.ConfirmSigExempt(ctx, position) {
+		return sdk.Result{}
+	}
+	return PlasmaMVPPolicy{}.CheckConfirmSig(ctx, utxoMapper, position, confirmSigs, posSignBytes, registry)
+}
+
+// ConfirmSigExempt reports whether position's confirm sig can be skipped
+// without verifying anything, i.e. whether it already carries a Tendermint
+// commit from two-thirds or more of the validator set.
+func (p TendermintFinalityPolicy) ConfirmSigExempt(ctx sdk.Context, position types.Position) bool {
+	found, validCommit := p.Headers.HasCommit(ctx, position.Blknum)
+	return found && validCommit
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"testing"
+
+	types "github.com/FourthState/plasma-mvp-sidechain/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	crypto "github.com/tendermint/go-crypto"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+type fakeHeaderStore struct {
+	found       bool
+	validCommit bool
+}
+
+func (f fakeHeaderStore) HasCommit(ctx sdk.Context, height uint64) (bool, bool) {
+	return f.found, f.validCommit
+}
+
+func TestTendermintFinalityPolicyConfirmSigExempt(t *testing.T) {
+	ctx := sdk.NewContext(nil, abci.Header{}, false, log.NewNopLogger())
+	position := types.Position{Blknum: 1}
+
+	cases := []struct {
+		name   string
+		store  fakeHeaderStore
+		exempt bool
+	}{
+		{"found with a valid commit", fakeHeaderStore{found: true, validCommit: true}, true},
+		{"found but not yet a valid commit", fakeHeaderStore{found: true, validCommit: false}, false},
+		{"not found", fakeHeaderStore{found: false, validCommit: true}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p := TendermintFinalityPolicy{Headers: c.store}
+			if got := p.ConfirmSigExempt(ctx, position); got != c.exempt {
+				t.Fatalf("ConfirmSigExempt = %v, want %v", got, c.exempt)
+			}
+		})
+	}
+}
+
+// TestTendermintFinalityPolicyCheckConfirmSigSkipsVerificationWhenExempt
+// checks that CheckConfirmSig short-circuits to an OK result once the
+// block is finalized, without ever consulting the UTXOMapper or
+// SchemeRegistry it was handed (both nil here) to verify a signature.
+func TestTendermintFinalityPolicyCheckConfirmSigSkipsVerificationWhenExempt(t *testing.T) {
+	ctx := sdk.NewContext(nil, abci.Header{}, false, log.NewNopLogger())
+	position := types.Position{Blknum: 1}
+	p := TendermintFinalityPolicy{Headers: fakeHeaderStore{found: true, validCommit: true}}
+
+	res := p.CheckConfirmSig(ctx, nil, position, [2]crypto.Signature{}, nil, nil)
+	if res.IsError() {
+		t.Fatalf("expected an OK result for an exempt position, got %+v", res)
+	}
+}
+
+// TestTendermintFinalityPolicyCheckConfirmSigFallsBackWhenNotExempt checks
+// that an unfinalized block still routes to PlasmaMVPPolicy's verification
+// instead of silently passing; an UTXOMapper that can't find the UTXO in
+// question is enough to prove the fallback ran, without needing to
+// construct a real UTXO fixture.
+func TestTendermintFinalityPolicyCheckConfirmSigFallsBackWhenNotExempt(t *testing.T) {
+	ctx := sdk.NewContext(nil, abci.Header{}, false, log.NewNopLogger())
+	position := types.Position{Blknum: 1}
+	p := TendermintFinalityPolicy{Headers: fakeHeaderStore{found: false}}
+
+	res := p.CheckConfirmSig(ctx, emptyUTXOMapper{}, position, [2]crypto.Signature{}, nil, NewSchemeRegistry())
+	if !res.IsError() {
+		t.Fatal("expected CheckConfirmSig to fall back to PlasmaMVPPolicy and fail to find the UTXO, not silently pass")
+	}
+}
+
+type emptyUTXOMapper struct{}
+
+func (emptyUTXOMapper) GetUTXO(ctx sdk.Context, position types.Position) types.UTXO {
+	return nil
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	types "github.com/FourthState/plasma-mvp-sidechain/types"
+)
+
+// AnteDecorator is one independent link in an ante-handling chain. It runs
+// its own check against ctx/tx and, if that check passes, calls next to
+// continue the chain; next is the no-op terminal handler for the last
+// decorator in a chain.
+type AnteDecorator interface {
+	AnteHandle(ctx sdk.Context, tx sdk.Tx, next sdk.AnteHandler) (newCtx sdk.Context, res sdk.Result, abort bool)
+}
+
+// ChainAnteDecorators composes decorators into a single sdk.AnteHandler,
+// each calling into the next only once its own check passes. This lets
+// operators add, remove, or reorder individual checks (such as the fee
+// policy) without forking the decorators around it.
+func ChainAnteDecorators(decorators ...AnteDecorator) sdk.AnteHandler {
+	if len(decorators) == 0 {
+		return func(ctx sdk.Context, tx sdk.Tx) (sdk.Context, sdk.Result, bool) {
+			return ctx, sdk.Result{}, false
+		}
+	}
+	return func(ctx sdk.Context, tx sdk.Tx) (sdk.Context, sdk.Result, bool) {
+		return decorators[0].AnteHandle(ctx, tx, ChainAnteDecorators(decorators[1:]...))
+	}
+}
+
+// ValidateBasicDecorator checks that the transaction is well-formed before
+// any message is dispatched: it must be a BaseTx and must carry at least
+// one signature. Per-message signer/signature counts are MultiMsgDecorator's
+// responsibility, since only it knows how many signers each message needs.
+type ValidateBasicDecorator struct{}
+
+func (ValidateBasicDecorator) AnteHandle(ctx sdk.Context, tx sdk.Tx, next sdk.AnteHandler) (sdk.Context, sdk.Result, bool) {
+	if _, ok := tx.(types.BaseTx); !ok {
+		return ctx, sdk.ErrInternal("tx must be in form of BaseTx").Result(), true
+	}
+
+	if len(tx.GetSignatures()) == 0 {
+		return ctx, sdk.ErrUnauthorized("no signers").Result(), true
+	}
+
+	return next(ctx, tx)
+}
+
+// IncrementFeeCounterDecorator accumulates a delivered transaction's total
+// fee, as computed by MultiMsgDecorator, into FeeAmount.
+type IncrementFeeCounterDecorator struct {
+	FeeAmount *uint64
+}
+
+func (d IncrementFeeCounterDecorator) AnteHandle(ctx sdk.Context, tx sdk.Tx, next sdk.AnteHandler) (sdk.Context, sdk.Result, bool) {
+	if !ctx.IsCheckTx() {
+		(*d.FeeAmount) += AggregateFee(ctx)
+	}
+
+	return next(ctx, tx)
+}
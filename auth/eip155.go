@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// secp256k1HalfN is half the order of the secp256k1 curve. Signatures whose
+// S value exceeds this are the non-canonical twin of an equally valid
+// signature and are rejected so the same spend cannot be replayed under two
+// different signature encodings (ECDSA malleability).
+var secp256k1HalfN = new(big.Int).Rsh(ethcrypto.S256().Params().N, 1)
+
+// eip155Hash binds signBytes to chainID the way EIP-155 binds an Ethereum
+// transaction to a network: the chain ID, followed by two zero elements, is
+// RLP-encoded and appended to the payload before hashing. A signature
+// produced over the result cannot be replayed against a sidechain running
+// under a different chain ID.
+func eip155Hash(signBytes []byte, chainID *big.Int) []byte {
+	suffix, err := rlp.EncodeToBytes([]interface{}{chainID, uint(0), uint(0)})
+	if err != nil {
+		panic(err)
+	}
+	return ethcrypto.Keccak256(append(signBytes, suffix...))
+}
+
+// recoverEIP155 splits a 65-byte (R, S, V) signature produced under EIP-155,
+// rejects malleable or out-of-range values, and recovers the signer's public
+// key from hash. V is expected to equal chainID*2+35 or chainID*2+36.
+func recoverEIP155(hash []byte, sig []byte, chainID *big.Int) (*ecdsa.PublicKey, error) {
+	if len(sig) != 65 {
+		return nil, fmt.Errorf("invalid signature length: got %d, want 65", len(sig))
+	}
+
+	r := sig[:32]
+	s := new(big.Int).SetBytes(sig[32:64])
+	v := new(big.Int).SetBytes(sig[64:65])
+
+	if s.Cmp(secp256k1HalfN) > 0 {
+		return nil, errors.New("signature S value is not in the canonical lower half of curve order")
+	}
+
+	recoveryID := new(big.Int).Sub(v, new(big.Int).Mul(chainID, big.NewInt(2)))
+	recoveryID.Sub(recoveryID, big.NewInt(35))
+	if recoveryID.Sign() < 0 || recoveryID.Cmp(big.NewInt(1)) > 0 {
+		return nil, fmt.Errorf("signature V value %s does not match chain ID %s", v, chainID)
+	}
+
+	normalized := make([]byte, 65)
+	copy(normalized[0:32], r)
+	copy(normalized[32:64], leftPad32(s))
+	normalized[64] = byte(recoveryID.Uint64())
+
+	return ethcrypto.SigToPub(hash, normalized)
+}
+
+// leftPad32 returns x's big-endian bytes zero-padded on the left to exactly
+// 32 bytes. x.Bytes() alone strips leading zero bytes, so copying it
+// directly into a fixed-size buffer would left-shift (rather than
+// zero-pad) any value under 2^248 — silently corrupting roughly 1 in 256
+// otherwise-valid S values.
+func leftPad32(x *big.Int) []byte {
+	buf := make([]byte, 32)
+	x.FillBytes(buf)
+	return buf
+}
+
+// recoverLegacy recovers the signer's public key from a pre-EIP-155
+// signature whose V byte is the raw recovery id (27/28), as produced by
+// UTXOs created before chain ID replay protection was enforced.
+func recoverLegacy(hash []byte, sig []byte) (*ecdsa.PublicKey, error) {
+	if len(sig) != 65 {
+		return nil, fmt.Errorf("invalid signature length: got %d, want 65", len(sig))
+	}
+
+	v := sig[64]
+	if v != 27 && v != 28 {
+		return nil, errors.New("not a legacy-style (V=27/28) signature")
+	}
+
+	normalized := make([]byte, 65)
+	copy(normalized, sig)
+	normalized[64] = v - 27
+
+	return ethcrypto.SigToPub(hash, normalized)
+}
+
+// recoverSig recovers the signer of signBytes from a wire signature, trying
+// the EIP-155 chain-bound form first and, only when allowLegacySigs is set,
+// falling back to the pre-upgrade legacy form so existing UTXOs stay
+// spendable across the upgrade.
+func recoverSig(signBytes []byte, sig []byte, chainID *big.Int, allowLegacySigs bool) (*ecdsa.PublicKey, error) {
+	pubKey, err := recoverEIP155(eip155Hash(signBytes, chainID), sig, chainID)
+	if err == nil {
+		return pubKey, nil
+	}
+	if !allowLegacySigs {
+		return nil, err
+	}
+	return recoverLegacy(ethcrypto.Keccak256(signBytes), sig)
+}
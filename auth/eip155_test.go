@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// TestLeftPad32 covers the case a plain copy(dst, x.Bytes()) gets wrong: a
+// value whose big-endian encoding is shorter than 32 bytes because it has
+// one or more leading zero bytes. math/big's Bytes() strips those leading
+// zeros, so copying it into a fixed-size buffer left-shifts the value
+// instead of zero-padding it on the left.
+func TestLeftPad32(t *testing.T) {
+	cases := []struct {
+		name string
+		x    *big.Int
+		want []byte
+	}{
+		{"zero", big.NewInt(0), make([]byte, 32)},
+		{"single byte", big.NewInt(1), append(make([]byte, 31), 0x01)},
+		{"many leading zero bytes", big.NewInt(42), append(make([]byte, 31), 0x2a)},
+		{"full width, no leading zeros", new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(1)), nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := leftPad32(c.x)
+			if len(got) != 32 {
+				t.Fatalf("len(leftPad32(%s)) = %d, want 32", c.x, len(got))
+			}
+			if c.want != nil && !bytesEqual(got, c.want) {
+				t.Fatalf("leftPad32(%s) = %x, want %x", c.x, got, c.want)
+			}
+			if new(big.Int).SetBytes(got).Cmp(c.x) != 0 {
+				t.Fatalf("leftPad32(%s) round-trips to %s", c.x, new(big.Int).SetBytes(got))
+			}
+		})
+	}
+}
+
+func bytesEqual(a, b []byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestRecoverEIP155LeadingZeroSByte reproduces the bug directly against
+// recoverEIP155 rather than just its padding helper: it signs messages with
+// a real secp256k1 key until it finds a signature whose S value has at
+// least one leading zero byte (about 1 in 256 signatures), then checks that
+// recoverEIP155 still recovers the signer's address. Before leftPad32, this
+// failed for any such signature.
+func TestRecoverEIP155LeadingZeroSByte(t *testing.T) {
+	key, err := ethcrypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key: %s", err)
+	}
+	expected := ethcrypto.PubkeyToAddress(key.PublicKey)
+	chainID := big.NewInt(1)
+
+	found := false
+	for i := 0; i < 4096 && !found; i++ {
+		signBytes := []byte(fmt.Sprintf("eip155 leading-zero-S regression %d", i))
+		boundHash := eip155Hash(signBytes, chainID)
+
+		sig, err := ethcrypto.Sign(boundHash, key)
+		if err != nil {
+			t.Fatalf("sign: %s", err)
+		}
+
+		s := new(big.Int).SetBytes(sig[32:64])
+		if s.Cmp(secp256k1HalfN) > 0 {
+			// Non-canonical; ethcrypto.Sign doesn't normalize this, but
+			// recoverEIP155 requires it, so flip to the canonical twin.
+			s.Sub(ethcrypto.S256().Params().N, s)
+			sig[64] ^= 1
+			copy(sig[32:64], leftPad32(s))
+		}
+		if len(s.Bytes()) == 32 {
+			continue
+		}
+		found = true
+
+		eip155Sig := make([]byte, 65)
+		copy(eip155Sig[0:32], sig[0:32])
+		copy(eip155Sig[32:64], sig[32:64])
+		eip155Sig[64] = byte(chainID.Uint64()*2 + 35 + uint64(sig[64]))
+
+		pubKey, err := recoverEIP155(boundHash, eip155Sig, chainID)
+		if err != nil {
+			t.Fatalf("recoverEIP155 with leading-zero-byte S: %s", err)
+		}
+		if got := ethcrypto.PubkeyToAddress(*pubKey); got != expected {
+			t.Fatalf("recovered address %x, want %x", got, expected)
+		}
+	}
+
+	if !found {
+		t.Fatal("did not find a signature with a leading-zero-byte S within 4096 attempts")
+	}
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	amino "github.com/tendermint/go-amino"
+)
+
+// priorityContextKey is the context key FeeDecorator stamps a
+// transaction's computed mempool priority under.
+type priorityContextKey struct{}
+
+// Priority returns the fee-per-byte priority FeeDecorator stamped onto ctx
+// during CheckTx, or zero if none was stamped.
+func Priority(ctx sdk.Context) int64 {
+	priority, _ := ctx.Value(priorityContextKey{}).(int64)
+	return priority
+}
+
+// FeeDecorator rejects CheckTx-bound transactions whose fee-per-byte falls
+// below MinFeePerByte, an operator-configured floor, and stamps the
+// computed fee/txSize priority onto the returned context so the Tendermint
+// mempool can order CheckTx-admitted transactions by it. It never rejects
+// during DeliverTx: by then the block proposer has already ordered and
+// included the transaction, so rejecting it would fork the chain.
+//
+// FeeDecorator must run after MultiMsgDecorator, which stamps the
+// transaction's total fee across every message onto the context.
+type FeeDecorator struct {
+	Codec         *amino.Codec
+	MinFeePerByte uint64
+}
+
+func (d FeeDecorator) AnteHandle(ctx sdk.Context, tx sdk.Tx, next sdk.AnteHandler) (sdk.Context, sdk.Result, bool) {
+	txBytes, err := d.Codec.MarshalBinaryLengthPrefixed(tx)
+	if err != nil {
+		return ctx, sdk.ErrInternal(fmt.Sprintf("failed to measure tx size: %s", err)).Result(), true
+	}
+	txSize := uint64(len(txBytes))
+
+	feePerByte := AggregateFee(ctx) / txSize
+
+	if ctx.IsCheckTx() && feePerByte < d.MinFeePerByte {
+		msg := fmt.Sprintf("insufficient fee: %d per byte, minimum is %d", feePerByte, d.MinFeePerByte)
+		return ctx, sdk.ErrInsufficientFee(msg).Result(), true
+	}
+
+	ctx = ctx.WithValue(priorityContextKey{}, int64(feePerByte))
+
+	return next(ctx, tx)
+}
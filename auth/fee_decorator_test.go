@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	amino "github.com/tendermint/go-amino"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+// feeDecoratorTestTx is the smallest sdk.Tx FeeDecorator needs: it never
+// looks past GetMsgs/GetSignatures, and only needs to amino-marshal to a
+// stable, known size so txSize is deterministic across test cases.
+type feeDecoratorTestTx struct {
+	Payload []byte
+}
+
+func (feeDecoratorTestTx) GetMsgs() []sdk.Msg                { return nil }
+func (feeDecoratorTestTx) GetSignatures() []sdk.StdSignature { return nil }
+
+func newFeeDecoratorTestCtx(isCheckTx bool) sdk.Context {
+	return sdk.NewContext(nil, abci.Header{}, isCheckTx, log.NewNopLogger())
+}
+
+func TestFeeDecoratorRejectsBelowFloorOnlyDuringCheckTx(t *testing.T) {
+	d := FeeDecorator{Codec: amino.NewCodec(), MinFeePerByte: 10}
+	tx := feeDecoratorTestTx{}
+
+	txBytes, err := d.Codec.MarshalBinaryLengthPrefixed(tx)
+	if err != nil {
+		t.Fatalf("marshal fixture tx: %s", err)
+	}
+	txSize := uint64(len(txBytes))
+
+	next := func(ctx sdk.Context, tx sdk.Tx) (sdk.Context, sdk.Result, bool) {
+		return ctx, sdk.Result{}, false
+	}
+
+	t.Run("below floor, CheckTx", func(t *testing.T) {
+		ctx := newFeeDecoratorTestCtx(true)
+		ctx = ctx.WithValue(aggregateFeeContextKey{}, (d.MinFeePerByte-1)*txSize)
+
+		_, res, abort := d.AnteHandle(ctx, tx, next)
+		if !abort {
+			t.Fatal("expected AnteHandle to reject a fee below the floor during CheckTx")
+		}
+		if !res.IsError() {
+			t.Fatalf("expected an error result, got %+v", res)
+		}
+	})
+
+	t.Run("at floor, CheckTx", func(t *testing.T) {
+		ctx := newFeeDecoratorTestCtx(true)
+		ctx = ctx.WithValue(aggregateFeeContextKey{}, d.MinFeePerByte*txSize)
+
+		newCtx, _, abort := d.AnteHandle(ctx, tx, next)
+		if abort {
+			t.Fatal("expected AnteHandle to accept a fee exactly at the floor")
+		}
+		if got := Priority(newCtx); got != int64(d.MinFeePerByte) {
+			t.Fatalf("priority = %d, want %d", got, d.MinFeePerByte)
+		}
+	})
+
+	t.Run("below floor, DeliverTx never rejects", func(t *testing.T) {
+		ctx := newFeeDecoratorTestCtx(false)
+		ctx = ctx.WithValue(aggregateFeeContextKey{}, uint64(0))
+
+		_, _, abort := d.AnteHandle(ctx, tx, next)
+		if abort {
+			t.Fatal("FeeDecorator must never reject during DeliverTx, the block is already proposed")
+		}
+	})
+}
+
+func TestFeeDecoratorStampsPriorityFromFeePerByte(t *testing.T) {
+	d := FeeDecorator{Codec: amino.NewCodec(), MinFeePerByte: 1}
+	// A non-empty payload keeps txSize above the +3 remainder added to the
+	// fee below, so integer division drops exactly that remainder.
+	tx := feeDecoratorTestTx{Payload: make([]byte, 16)}
+
+	txBytes, err := d.Codec.MarshalBinaryLengthPrefixed(tx)
+	if err != nil {
+		t.Fatalf("marshal fixture tx: %s", err)
+	}
+	txSize := uint64(len(txBytes))
+
+	ctx := newFeeDecoratorTestCtx(true)
+	ctx = ctx.WithValue(aggregateFeeContextKey{}, 5*txSize+3)
+
+	next := func(ctx sdk.Context, tx sdk.Tx) (sdk.Context, sdk.Result, bool) {
+		return ctx, sdk.Result{}, false
+	}
+
+	newCtx, _, abort := d.AnteHandle(ctx, tx, next)
+	if abort {
+		t.Fatal("unexpected rejection")
+	}
+	if got, want := Priority(newCtx), int64(5); got != want {
+		t.Fatalf("priority = %d, want %d (integer division of fee by txSize)", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	types "github.com/FourthState/plasma-mvp-sidechain/types"
+)
+
+// aggregateFeeContextKey is the context key MultiMsgDecorator stamps a
+// transaction's total fee under, for FeeDecorator and
+// IncrementFeeCounterDecorator to read back out.
+type aggregateFeeContextKey struct{}
+
+// AggregateFee returns the total fee MultiMsgDecorator stamped onto ctx
+// across every message in the transaction.
+func AggregateFee(ctx sdk.Context) uint64 {
+	fee, _ := ctx.Value(aggregateFeeContextKey{}).(uint64)
+	return fee
+}
+
+// MultiMsgDecorator dispatches each message in the transaction to the
+// MsgHandler Router has registered for its Type(), instead of hard-casting
+// tx.GetMsg() to a single SpendMsg. Each message consumes as many
+// signatures, in order, as it has signers (tx.GetSignatures() is the flat
+// concatenation of every message's signer signatures) so a single tx can
+// batch, e.g., a DepositMsg and a SpendMsg together atomically. The sum of
+// every handler's reported fee is stamped onto the context for the rest of
+// the chain.
+type MultiMsgDecorator struct {
+	Router *MsgRouter
+}
+
+func (d MultiMsgDecorator) AnteHandle(ctx sdk.Context, tx sdk.Tx, next sdk.AnteHandler) (sdk.Context, sdk.Result, bool) {
+	if _, ok := tx.(types.BaseTx); !ok {
+		return ctx, sdk.ErrInternal("tx must be in form of BaseTx").Result(), true
+	}
+
+	msgs := tx.GetMsgs()
+	if len(msgs) == 0 {
+		return ctx, sdk.ErrUnknownRequest("tx must carry at least one message").Result(), true
+	}
+
+	sigs := tx.GetSignatures()
+	var totalFee uint64
+	offset := 0
+
+	for i, msg := range msgs {
+		handler, ok := d.Router.Route(msg.Type())
+		if !ok {
+			return ctx, sdk.ErrUnknownRequest(fmt.Sprintf("no handler registered for message type %q", msg.Type())).Result(), true
+		}
+
+		signerAddrs := msg.GetSigners()
+		if offset+len(signerAddrs) > len(sigs) {
+			return ctx, sdk.ErrUnauthorized(fmt.Sprintf("message %d: wrong number of signers", i)).Result(), true
+		}
+		msgSigs := sigs[offset : offset+len(signerAddrs)]
+		offset += len(signerAddrs)
+
+		fee, res := handler.AnteHandle(ctx, msg, signerAddrs, msgSigs)
+		if !res.IsOK() {
+			return ctx, res, true
+		}
+		totalFee += fee
+	}
+
+	if offset != len(sigs) {
+		return ctx, sdk.ErrUnauthorized("wrong number of signers").Result(), true
+	}
+
+	ctx = ctx.WithValue(aggregateFeeContextKey{}, totalFee)
+	return next(ctx, tx)
+}
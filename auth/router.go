@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"fmt"
+	"reflect"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	crypto "github.com/tendermint/go-crypto"
+	types "github.com/FourthState/plasma-mvp-sidechain/types"
+	utils "github.com/FourthState/plasma-mvp-sidechain/utils"
+)
+
+// Message route names for MsgRouter, matching each message's Type().
+const (
+	RouteSpend        = "spend"
+	RouteDeposit      = "deposit"
+	RouteExit         = "exit"
+	RouteIncludeBlock = "includeBlock"
+)
+
+// MsgHandler verifies one message's signature(s), and any other
+// message-specific authorization, against the signer addresses and
+// signatures a MultiMsgDecorator sliced out for it. It reports the fee the
+// message should contribute to the transaction's total.
+type MsgHandler interface {
+	AnteHandle(ctx sdk.Context, msg sdk.Msg, signerAddrs []crypto.Address, sigs []sdk.StdSignature) (fee uint64, res sdk.Result)
+}
+
+// MsgRouter dispatches each message in a transaction to the MsgHandler
+// registered for its Type(), the same route-by-type pattern the SDK uses
+// for its own message handlers. This lets SpendMsg, DepositMsg, ExitMsg,
+// and IncludeBlockMsg be batched into one atomic tx instead of each
+// requiring a tx of its own.
+type MsgRouter struct {
+	routes map[string]MsgHandler
+}
+
+// NewMsgRouter returns an empty router. Use AddRoute to populate it, or
+// NewDefaultMsgRouter for the routes this sidechain ships with.
+func NewMsgRouter() *MsgRouter {
+	return &MsgRouter{routes: make(map[string]MsgHandler)}
+}
+
+// AddRoute registers handler for msgType, panicking if a handler is
+// already registered for it (the same fail-fast behavior as the SDK's own
+// router on a duplicate route).
+func (r *MsgRouter) AddRoute(msgType string, handler MsgHandler) *MsgRouter {
+	if _, ok := r.routes[msgType]; ok {
+		panic(fmt.Sprintf("route already registered for message type %q", msgType))
+	}
+	r.routes[msgType] = handler
+	return r
+}
+
+// Route returns the handler registered for msgType, if any.
+func (r *MsgRouter) Route(msgType string) (MsgHandler, bool) {
+	h, ok := r.routes[msgType]
+	return h, ok
+}
+
+// NewDefaultMsgRouter returns the router NewAnteHandler uses: SpendMsg
+// keeps today's spend + confirm-sig verification, DepositMsg verifies only
+// the depositor's own signature, ExitMsg verifies the exiter owns the
+// referenced UTXO, and IncludeBlockMsg is restricted to operatorAddr.
+func NewDefaultMsgRouter(utxoMapper types.UTXOMapper, registry *SchemeRegistry, confirmSigPolicy ConfirmSigPolicy, operatorAddr crypto.Address) *MsgRouter {
+	return NewMsgRouter().
+		AddRoute(RouteSpend, SpendMsgHandler{UTXOMapper: utxoMapper, Registry: registry, ConfirmSigPolicy: confirmSigPolicy}).
+		AddRoute(RouteDeposit, DepositMsgHandler{Registry: registry}).
+		AddRoute(RouteExit, ExitMsgHandler{UTXOMapper: utxoMapper, Registry: registry}).
+		AddRoute(RouteIncludeBlock, IncludeBlockMsgHandler{OperatorAddr: operatorAddr, Registry: registry})
+}
+
+// recoverAndCompare looks up signBytes' scheme from its wire-encoded
+// signature and checks that it recovers to expected, the pattern every
+// MsgHandler below uses once it has decided which address must have
+// signed.
+func recoverAndCompare(registry *SchemeRegistry, signBytes []byte, sig sdk.StdSignature, expected crypto.Address) sdk.Result {
+	scheme, rawSig, err := registry.Lookup(sig.Signature.Bytes()[5:])
+	if err != nil {
+		return sdk.ErrUnauthorized(err.Error()).Result()
+	}
+	if err := verifyScheme(scheme, signBytes, rawSig, expected); err != nil {
+		return sdk.ErrUnauthorized("signature verification failed").Result()
+	}
+	return sdk.Result{}
+}
+
+// SpendMsgHandler verifies a SpendMsg exactly as the original ante handler
+// did before message routing existed: both inputs' spend signatures, their
+// confirmation signatures (per ConfirmSigPolicy), and it reports the
+// spend's fee.
+type SpendMsgHandler struct {
+	UTXOMapper       types.UTXOMapper
+	Registry         *SchemeRegistry
+	ConfirmSigPolicy ConfirmSigPolicy
+}
+
+func (h SpendMsgHandler) AnteHandle(ctx sdk.Context, msg sdk.Msg, signerAddrs []crypto.Address, sigs []sdk.StdSignature) (uint64, sdk.Result) {
+	spendMsg, ok := msg.(types.SpendMsg)
+	if !ok {
+		return 0, sdk.ErrInternal("expected a SpendMsg").Result()
+	}
+
+	policy := h.ConfirmSigPolicy
+	if policy == nil {
+		policy = PlasmaMVPPolicy{}
+	}
+
+	signBytes := spendMsg.GetSignBytes()
+
+	position1 := types.Position{spendMsg.Blknum1, spendMsg.Txindex1, spendMsg.Oindex1, spendMsg.DepositNum1}
+	if res := processSig(ctx, h.UTXOMapper, position1, signerAddrs[0], sigs[0], signBytes, h.Registry); !res.IsOK() {
+		return 0, res
+	}
+	if res := policy.CheckConfirmSig(ctx, h.UTXOMapper, position1, spendMsg.ConfirmSigs1, position1.GetSignBytes(), h.Registry); !res.IsOK() {
+		return 0, res
+	}
+
+	if utils.ValidAddress(spendMsg.Owner2) {
+		position2 := types.Position{spendMsg.Blknum2, spendMsg.Txindex2, spendMsg.Oindex2, spendMsg.DepositNum2}
+		if res := processSig(ctx, h.UTXOMapper, position2, signerAddrs[1], sigs[1], signBytes, h.Registry); !res.IsOK() {
+			return 0, res
+		}
+		if res := policy.CheckConfirmSig(ctx, h.UTXOMapper, position2, spendMsg.ConfirmSigs2, position2.GetSignBytes(), h.Registry); !res.IsOK() {
+			return 0, res
+		}
+	}
+
+	return spendMsg.Fee, sdk.Result{}
+}
+
+// DepositMsgHandler verifies only that the depositor's own signature
+// authorizes the deposit. There is no UTXO to confirm: the funds are
+// arriving from the root chain for the first time, so there is no prior
+// owner to withhold a confirmation from.
+type DepositMsgHandler struct {
+	Registry *SchemeRegistry
+}
+
+func (h DepositMsgHandler) AnteHandle(ctx sdk.Context, msg sdk.Msg, signerAddrs []crypto.Address, sigs []sdk.StdSignature) (uint64, sdk.Result) {
+	depositMsg, ok := msg.(types.DepositMsg)
+	if !ok {
+		return 0, sdk.ErrInternal("expected a DepositMsg").Result()
+	}
+	if res := recoverAndCompare(h.Registry, depositMsg.GetSignBytes(), sigs[0], depositMsg.Depositor); !res.IsOK() {
+		return 0, res
+	}
+	return 0, sdk.Result{}
+}
+
+// ExitMsgHandler verifies the exiter's signature matches the owner of the
+// UTXO being exited. ExitMsg carries no fee of its own; exit bonds are
+// handled by the root-chain contract, not this ante handler.
+type ExitMsgHandler struct {
+	UTXOMapper types.UTXOMapper
+	Registry   *SchemeRegistry
+}
+
+func (h ExitMsgHandler) AnteHandle(ctx sdk.Context, msg sdk.Msg, signerAddrs []crypto.Address, sigs []sdk.StdSignature) (uint64, sdk.Result) {
+	exitMsg, ok := msg.(types.ExitMsg)
+	if !ok {
+		return 0, sdk.ErrInternal("expected an ExitMsg").Result()
+	}
+
+	utxo := h.UTXOMapper.GetUTXO(ctx, exitMsg.Position)
+	if utxo == nil {
+		return 0, sdk.ErrUnknownRequest("UTXO trying to be exited, does not exist").Result()
+	}
+	if !reflect.DeepEqual(utxo.GetAddress().Bytes(), signerAddrs[0].Bytes()) {
+		return 0, sdk.ErrUnauthorized("exiter does not own the referenced utxo").Result()
+	}
+
+	if res := recoverAndCompare(h.Registry, exitMsg.GetSignBytes(), sigs[0], signerAddrs[0]); !res.IsOK() {
+		return 0, res
+	}
+	return 0, sdk.Result{}
+}
+
+// IncludeBlockMsgHandler restricts IncludeBlockMsg to a single fixed
+// operator key: only the chain operator may commit a new Plasma block's
+// header.
+type IncludeBlockMsgHandler struct {
+	OperatorAddr crypto.Address
+	Registry     *SchemeRegistry
+}
+
+func (h IncludeBlockMsgHandler) AnteHandle(ctx sdk.Context, msg sdk.Msg, signerAddrs []crypto.Address, sigs []sdk.StdSignature) (uint64, sdk.Result) {
+	includeMsg, ok := msg.(types.IncludeBlockMsg)
+	if !ok {
+		return 0, sdk.ErrInternal("expected an IncludeBlockMsg").Result()
+	}
+	if !reflect.DeepEqual(signerAddrs[0].Bytes(), h.OperatorAddr.Bytes()) {
+		return 0, sdk.ErrUnauthorized("only the operator may include a block").Result()
+	}
+	if res := recoverAndCompare(h.Registry, includeMsg.GetSignBytes(), sigs[0], h.OperatorAddr); !res.IsOK() {
+		return 0, res
+	}
+	return 0, sdk.Result{}
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	crypto "github.com/tendermint/go-crypto"
+)
+
+// dummyMsgHandler is the smallest MsgHandler that lets router_test.go
+// exercise registration/dispatch without depending on any concrete
+// sdk.Msg type.
+type dummyMsgHandler struct {
+	fee uint64
+}
+
+func (h dummyMsgHandler) AnteHandle(ctx sdk.Context, msg sdk.Msg, signerAddrs []crypto.Address, sigs []sdk.StdSignature) (uint64, sdk.Result) {
+	return h.fee, sdk.Result{}
+}
+
+func TestMsgRouterAddAndRoute(t *testing.T) {
+	r := NewMsgRouter()
+	handler := dummyMsgHandler{fee: 7}
+	r.AddRoute(RouteSpend, handler)
+
+	got, ok := r.Route(RouteSpend)
+	if !ok {
+		t.Fatal("expected a handler registered for RouteSpend")
+	}
+	if got != handler {
+		t.Fatalf("got handler %+v, want %+v", got, handler)
+	}
+}
+
+func TestMsgRouterRouteMissing(t *testing.T) {
+	r := NewMsgRouter()
+	if _, ok := r.Route(RouteDeposit); ok {
+		t.Fatal("expected no handler registered for an unused route")
+	}
+}
+
+func TestMsgRouterAddRouteChains(t *testing.T) {
+	r := NewMsgRouter().
+		AddRoute(RouteSpend, dummyMsgHandler{fee: 1}).
+		AddRoute(RouteDeposit, dummyMsgHandler{fee: 2})
+
+	if _, ok := r.Route(RouteSpend); !ok {
+		t.Fatal("expected RouteSpend to be registered")
+	}
+	if _, ok := r.Route(RouteDeposit); !ok {
+		t.Fatal("expected RouteDeposit to be registered")
+	}
+}
+
+func TestMsgRouterAddRouteDuplicatePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected AddRoute to panic on a duplicate route")
+		}
+	}()
+
+	NewMsgRouter().
+		AddRoute(RouteExit, dummyMsgHandler{}).
+		AddRoute(RouteExit, dummyMsgHandler{})
+}
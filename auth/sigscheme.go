@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	crypto "github.com/tendermint/go-crypto"
+)
+
+// SignatureScheme verifies a wire signature produced by a single signer.
+// Schemes that support public key recovery (e.g. Ethereum's
+// secp256k1+Keccak256) implement RecoverAddress directly; schemes that
+// cannot recover a key from a signature alone (e.g. an external hardware
+// wallet attestation) return an error from RecoverAddress and are instead
+// checked against an expected address by the caller.
+type SignatureScheme interface {
+	// RecoverAddress recovers the address that produced sig over signBytes.
+	RecoverAddress(signBytes []byte, sig []byte) (crypto.Address, error)
+}
+
+// AggregateScheme is implemented by schemes that can verify a single
+// signature against more than one signer at once, such as BLS aggregate
+// confirmation signatures covering both inputs of a two-input spend.
+type AggregateScheme interface {
+	SignatureScheme
+	VerifyAggregate(addrs []crypto.Address, signBytes []byte, aggSig []byte) error
+}
+
+// AttestedScheme is implemented by schemes, such as externalVerifierScheme,
+// that cannot recover a signer's address from a signature alone and instead
+// check it against an expected address via an out-of-band attestation.
+// verifyScheme type-asserts against this the same way callers type-assert
+// against AggregateScheme, so a scheme registered under PrefixExternalVerifier
+// is actually reachable instead of always failing RecoverAddress.
+type AttestedScheme interface {
+	SignatureScheme
+	VerifyAttested(addr crypto.Address, signBytes []byte, wireSig []byte) error
+}
+
+// verifyScheme checks wireSig over signBytes against expected, the one
+// entry point every caller in this package uses once it has decided which
+// address must have signed: it defers to scheme's attestation check if
+// scheme is an AttestedScheme, and to RecoverAddress otherwise.
+func verifyScheme(scheme SignatureScheme, signBytes []byte, wireSig []byte, expected crypto.Address) error {
+	if attested, ok := scheme.(AttestedScheme); ok {
+		return attested.VerifyAttested(expected, signBytes, wireSig)
+	}
+	recovered, err := scheme.RecoverAddress(signBytes, wireSig)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(recovered.Bytes(), expected.Bytes()) {
+		return errors.New("recovered address does not match expected signer")
+	}
+	return nil
+}
+
+// Wire signature scheme prefixes. The byte immediately following the amino
+// signature encoding selects which SignatureScheme verifies the remainder.
+const (
+	PrefixEthSecp256k1     byte = 0x01
+	PrefixBLSAggregate     byte = 0x02
+	PrefixExternalVerifier byte = 0x03
+)
+
+// SchemeRegistry maps a wire signature's scheme prefix byte to the
+// SignatureScheme that verifies it, so the ante handler never has to
+// hard-code a particular signing algorithm.
+type SchemeRegistry struct {
+	schemes map[byte]SignatureScheme
+}
+
+// NewSchemeRegistry returns an empty registry. Use Register to populate it,
+// or NewDefaultSchemeRegistry for the schemes this sidechain ships with.
+func NewSchemeRegistry() *SchemeRegistry {
+	return &SchemeRegistry{schemes: make(map[byte]SignatureScheme)}
+}
+
+// Register associates a SignatureScheme with a wire prefix byte, replacing
+// any scheme previously registered under that prefix.
+func (r *SchemeRegistry) Register(prefix byte, scheme SignatureScheme) {
+	r.schemes[prefix] = scheme
+}
+
+// Lookup reads the scheme prefix off the front of wireSig and returns the
+// scheme registered for it along with the remaining (unprefixed) signature
+// bytes that scheme expects.
+func (r *SchemeRegistry) Lookup(wireSig []byte) (SignatureScheme, []byte, error) {
+	if len(wireSig) < 1 {
+		return nil, nil, errors.New("signature too short to carry a scheme prefix")
+	}
+	scheme, ok := r.schemes[wireSig[0]]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown signature scheme prefix: 0x%02x", wireSig[0])
+	}
+	return scheme, wireSig[1:], nil
+}
+
+// NewDefaultSchemeRegistry returns the registry used by NewAnteHandler:
+// Ethereum secp256k1+Keccak256 under PrefixEthSecp256k1, BLS12-381
+// aggregate confirmation signatures under PrefixBLSAggregate, and a
+// passthrough verifier for hardware wallets under PrefixExternalVerifier.
+// chainID and allowLegacySigs configure the secp256k1 scheme exactly as
+// they configured NewAnteHandler before the registry existed; blsKeys
+// resolves an address to the BLS public key it confirms with, and attestor
+// checks hardware wallet attestations.
+func NewDefaultSchemeRegistry(chainID *big.Int, allowLegacySigs bool, blsKeys BLSKeyStore, attestor ExternalAttestor) *SchemeRegistry {
+	r := NewSchemeRegistry()
+	r.Register(PrefixEthSecp256k1, ethSecp256k1Scheme{chainID: chainID, allowLegacySigs: allowLegacySigs})
+	r.Register(PrefixBLSAggregate, blsAggregateScheme{keys: blsKeys})
+	r.Register(PrefixExternalVerifier, externalVerifierScheme{attestor: attestor})
+	return r
+}
+
+// ethSecp256k1Scheme is today's scheme: an EIP-155 chain-bound secp256k1
+// signature over eip155Hash(signBytes, chainID), recovered directly to an
+// address. When allowLegacySigs is set, a legacy (V=27/28) signature over
+// Keccak256(signBytes) is also accepted.
+type ethSecp256k1Scheme struct {
+	chainID         *big.Int
+	allowLegacySigs bool
+}
+
+func (s ethSecp256k1Scheme) RecoverAddress(signBytes []byte, sig []byte) (crypto.Address, error) {
+	pubKey, err := recoverSig(signBytes, sig, s.chainID, s.allowLegacySigs)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Address(ethcrypto.PubkeyToAddress(*pubKey).Bytes()), nil
+}
+
+// BLSKeyStore resolves the BLS12-381 public key an address confirms spends
+// with. Addresses remain the identity the UTXO set tracks; the BLS key is
+// only needed at confirm-sig verification time.
+type BLSKeyStore interface {
+	BLSPubKey(addr crypto.Address) (pubKey []byte, found bool)
+}
+
+// blsAggregateScheme verifies a single 96-byte BLS12-381 signature against
+// the sum of the confirming addresses' public keys, letting both
+// confirmation signatures on a two-input spend collapse into one:
+// e(g1, sig) == e(pk1 + pk2, H(signBytes)).
+type blsAggregateScheme struct {
+	keys BLSKeyStore
+}
+
+func (s blsAggregateScheme) RecoverAddress(signBytes []byte, sig []byte) (crypto.Address, error) {
+	return nil, errors.New("BLS aggregate signatures do not support address recovery, use VerifyAggregate")
+}
+
+func (s blsAggregateScheme) VerifyAggregate(addrs []crypto.Address, signBytes []byte, aggSig []byte) error {
+	if len(aggSig) != 96 {
+		return fmt.Errorf("invalid BLS aggregate signature length: got %d, want 96", len(aggSig))
+	}
+
+	pubKeys := make([][]byte, 0, len(addrs))
+	for _, addr := range addrs {
+		pubKey, found := s.keys.BLSPubKey(addr)
+		if !found {
+			return fmt.Errorf("no BLS public key registered for address %X", addr.Bytes())
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+
+	return bls12381VerifyAggregate(pubKeys, signBytes, aggSig)
+}
+
+// ExternalAttestor checks a hardware wallet's attestation that it produced
+// sig over signBytes on behalf of addr. Hardware wallets ship only a
+// recoverable signature plus a vendor attestation blob rather than
+// participating in key recovery directly.
+type ExternalAttestor interface {
+	Attest(addr crypto.Address, signBytes []byte, sig []byte, attestation []byte) error
+}
+
+// externalVerifierScheme passes verification through to an out-of-band
+// attestor instead of recovering a key from the signature itself.
+type externalVerifierScheme struct {
+	attestor ExternalAttestor
+}
+
+func (s externalVerifierScheme) RecoverAddress(signBytes []byte, sig []byte) (crypto.Address, error) {
+	return nil, errors.New("external verifier scheme requires an expected address, use VerifyAttested")
+}
+
+// VerifyAttested checks sig (a recoverable secp256k1 signature) plus its
+// trailing vendor attestation against the expected signer addr.
+func (s externalVerifierScheme) VerifyAttested(addr crypto.Address, signBytes []byte, wireSig []byte) error {
+	if len(wireSig) < 65 {
+		return errors.New("external verifier signature missing recoverable signature bytes")
+	}
+	return s.attestor.Attest(addr, signBytes, wireSig[:65], wireSig[65:])
+}
@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	crypto "github.com/tendermint/go-crypto"
+)
+
+func TestSchemeRegistryLookup(t *testing.T) {
+	r := NewSchemeRegistry()
+	scheme := ethSecp256k1Scheme{chainID: big.NewInt(1)}
+	r.Register(PrefixEthSecp256k1, scheme)
+
+	t.Run("registered prefix", func(t *testing.T) {
+		got, rest, err := r.Lookup([]byte{PrefixEthSecp256k1, 0xAA, 0xBB})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got != scheme {
+			t.Fatalf("got scheme %v, want %v", got, scheme)
+		}
+		if len(rest) != 2 || rest[0] != 0xAA || rest[1] != 0xBB {
+			t.Fatalf("rest = %x, want the bytes after the prefix", rest)
+		}
+	})
+
+	t.Run("unregistered prefix", func(t *testing.T) {
+		if _, _, err := r.Lookup([]byte{0x7F, 0x00}); err == nil {
+			t.Fatal("expected an error for an unregistered scheme prefix")
+		}
+	})
+
+	t.Run("empty wire signature", func(t *testing.T) {
+		if _, _, err := r.Lookup(nil); err == nil {
+			t.Fatal("expected an error for a wire signature too short to carry a prefix")
+		}
+	})
+}
+
+func TestEthSecp256k1SchemeRecoverAddress(t *testing.T) {
+	chainID := big.NewInt(1)
+	scheme := ethSecp256k1Scheme{chainID: chainID}
+
+	key, err := ethcrypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key: %s", err)
+	}
+	expected := crypto.Address(ethcrypto.PubkeyToAddress(key.PublicKey).Bytes())
+
+	signBytes := []byte("recover address fixture")
+	hash := eip155Hash(signBytes, chainID)
+	rawSig, err := ethcrypto.Sign(hash, key)
+	if err != nil {
+		t.Fatalf("sign: %s", err)
+	}
+	s := new(big.Int).SetBytes(rawSig[32:64])
+	if s.Cmp(secp256k1HalfN) > 0 {
+		s.Sub(ethcrypto.S256().Params().N, s)
+		rawSig[64] ^= 1
+		copy(rawSig[32:64], leftPad32(s))
+	}
+	eip155Sig := make([]byte, 65)
+	copy(eip155Sig[0:32], rawSig[0:32])
+	copy(eip155Sig[32:64], rawSig[32:64])
+	eip155Sig[64] = byte(chainID.Uint64()*2 + 35 + uint64(rawSig[64]))
+
+	t.Run("valid signature", func(t *testing.T) {
+		addr, err := scheme.RecoverAddress(signBytes, eip155Sig)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if addr.String() != expected.String() {
+			t.Fatalf("recovered %x, want %x", addr, expected)
+		}
+	})
+
+	t.Run("wrong chain ID", func(t *testing.T) {
+		wrongChainScheme := ethSecp256k1Scheme{chainID: big.NewInt(2)}
+		if _, err := wrongChainScheme.RecoverAddress(signBytes, eip155Sig); err == nil {
+			t.Fatal("expected an error recovering a signature bound to a different chain ID")
+		}
+	})
+
+	t.Run("truncated signature", func(t *testing.T) {
+		if _, err := scheme.RecoverAddress(signBytes, eip155Sig[:64]); err == nil {
+			t.Fatal("expected an error for a short signature")
+		}
+	})
+}
+
+func TestVerifySchemeDispatch(t *testing.T) {
+	chainID := big.NewInt(1)
+	plain := ethSecp256k1Scheme{chainID: chainID}
+
+	key, err := ethcrypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate key: %s", err)
+	}
+	addr := crypto.Address(ethcrypto.PubkeyToAddress(key.PublicKey).Bytes())
+	other := crypto.Address(append([]byte(nil), addr.Bytes()...))
+	other[0] ^= 0xFF
+
+	signBytes := []byte("verifyScheme dispatch fixture")
+	hash := eip155Hash(signBytes, chainID)
+	rawSig, err := ethcrypto.Sign(hash, key)
+	if err != nil {
+		t.Fatalf("sign: %s", err)
+	}
+	s := new(big.Int).SetBytes(rawSig[32:64])
+	if s.Cmp(secp256k1HalfN) > 0 {
+		s.Sub(ethcrypto.S256().Params().N, s)
+		rawSig[64] ^= 1
+		copy(rawSig[32:64], leftPad32(s))
+	}
+	eip155Sig := make([]byte, 65)
+	copy(eip155Sig[0:32], rawSig[0:32])
+	copy(eip155Sig[32:64], rawSig[32:64])
+	eip155Sig[64] = byte(chainID.Uint64()*2 + 35 + uint64(rawSig[64]))
+
+	t.Run("recover-based scheme matching address", func(t *testing.T) {
+		if err := verifyScheme(plain, signBytes, eip155Sig, addr); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	})
+
+	t.Run("recover-based scheme, wrong expected address", func(t *testing.T) {
+		if err := verifyScheme(plain, signBytes, eip155Sig, other); err == nil {
+			t.Fatal("expected an error when the recovered address does not match")
+		}
+	})
+
+	t.Run("attested scheme defers to VerifyAttested, not RecoverAddress", func(t *testing.T) {
+		attestor := fakeAttestor{}
+		scheme := externalVerifierScheme{attestor: &attestor}
+		wireSig := append(append([]byte{}, eip155Sig...), []byte("vendor-attestation")...)
+
+		if err := verifyScheme(scheme, signBytes, wireSig, addr); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !attestor.called {
+			t.Fatal("verifyScheme did not call VerifyAttested for an AttestedScheme")
+		}
+		if attestor.gotAddr.String() != addr.String() {
+			t.Fatalf("attestor saw address %x, want %x", attestor.gotAddr, addr)
+		}
+	})
+}
+
+type fakeAttestor struct {
+	called  bool
+	gotAddr crypto.Address
+	err     error
+}
+
+func (f *fakeAttestor) Attest(addr crypto.Address, signBytes []byte, sig []byte, attestation []byte) error {
+	f.called = true
+	f.gotAddr = addr
+	return f.err
+}
+
+func TestExternalVerifierScheme(t *testing.T) {
+	addr := crypto.Address([]byte("some-test-address-000000"))
+
+	t.Run("RecoverAddress always errors", func(t *testing.T) {
+		scheme := externalVerifierScheme{}
+		if _, err := scheme.RecoverAddress(nil, nil); err == nil {
+			t.Fatal("expected externalVerifierScheme.RecoverAddress to always error")
+		}
+	})
+
+	t.Run("VerifyAttested rejects a wireSig too short to carry a recoverable signature", func(t *testing.T) {
+		scheme := externalVerifierScheme{attestor: &fakeAttestor{}}
+		if err := scheme.VerifyAttested(addr, []byte("sign bytes"), make([]byte, 10)); err == nil {
+			t.Fatal("expected an error for a too-short wire signature")
+		}
+	})
+
+	t.Run("VerifyAttested splits the recoverable sig from the attestation and delegates", func(t *testing.T) {
+		attestor := fakeAttestor{}
+		scheme := externalVerifierScheme{attestor: &attestor}
+		wireSig := append(make([]byte, 65), []byte("attestation-blob")...)
+
+		if err := scheme.VerifyAttested(addr, []byte("sign bytes"), wireSig); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !attestor.called {
+			t.Fatal("expected the attestor to be called")
+		}
+	})
+
+	t.Run("VerifyAttested propagates the attestor's error", func(t *testing.T) {
+		attestor := fakeAttestor{err: errors.New("attestation rejected")}
+		scheme := externalVerifierScheme{attestor: &attestor}
+		wireSig := make([]byte, 70)
+
+		if err := scheme.VerifyAttested(addr, []byte("sign bytes"), wireSig); err == nil {
+			t.Fatal("expected the attestor's error to propagate")
+		}
+	})
+}
+
+func TestBLSAggregateSchemeRecoverAddressAlwaysErrors(t *testing.T) {
+	scheme := blsAggregateScheme{}
+	if _, err := scheme.RecoverAddress(nil, nil); err == nil {
+		t.Fatal("expected blsAggregateScheme.RecoverAddress to always error, use VerifyAggregate")
+	}
+}
+
+func TestBLSAggregateSchemeVerifyAggregateRejectsWrongLength(t *testing.T) {
+	scheme := blsAggregateScheme{keys: fakeBLSKeyStore{}}
+	addrs := []crypto.Address{crypto.Address([]byte("addr-a")), crypto.Address([]byte("addr-b"))}
+
+	if err := scheme.VerifyAggregate(addrs, []byte("sign bytes"), make([]byte, 10)); err == nil {
+		t.Fatal("expected an error for an aggregate signature that isn't 96 bytes")
+	}
+}
+
+func TestBLSAggregateSchemeVerifyAggregateMissingKey(t *testing.T) {
+	scheme := blsAggregateScheme{keys: fakeBLSKeyStore{}}
+	addrs := []crypto.Address{crypto.Address([]byte("addr-with-no-registered-key"))}
+
+	if err := scheme.VerifyAggregate(addrs, []byte("sign bytes"), make([]byte, 96)); err == nil {
+		t.Fatal("expected an error when an address has no registered BLS public key")
+	}
+}
+
+type fakeBLSKeyStore struct{}
+
+func (fakeBLSKeyStore) BLSPubKey(addr crypto.Address) ([]byte, bool) {
+	return nil, false
+}